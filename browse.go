@@ -0,0 +1,177 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithBrowse returns a Configurer that enables Site.BrowseDirs, e.g.:
+//
+//	site.Configure(web.WithBrowse())
+func WithBrowse() Configurer {
+	return ConfigureFunc(func(ctx context.Context, s *Site) error {
+		s.BrowseDirs = true
+		return nil
+	})
+}
+
+type browseEntry struct {
+	Name    string
+	IsDir   bool
+	Size    string
+	ModTime time.Time
+}
+
+var defaultBrowseTemplate = template.Must(template.New("browse").Parse(`<!doctype html>
+<html>
+<head><meta charset="utf-8"><title>Index of {{.Path}}</title></head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<ul>
+{{if .Parent}}<li><a href="{{.Parent}}">..</a></li>{{end}}
+{{range .Entries}}<li><a href="{{.Name}}{{if .IsDir}}/{{end}}">{{.Name}}{{if .IsDir}}/{{end}}</a> — {{.Size}} — {{.ModTime.Format "2006-01-02 15:04:05"}}</li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// serveBrowse renders a directory listing for r.URL.Path when it maps to
+// a directory without an index.html, reporting whether it handled the
+// request. Callers should only invoke it when s.BrowseDirs is set.
+func (s *Site) serveBrowse(w http.ResponseWriter, r *http.Request, dir http.Dir) bool {
+	f, err := dir.Open(r.URL.Path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || !info.IsDir() {
+		return false
+	}
+
+	if idx, err := dir.Open(path.Join(r.URL.Path, "index.html")); err == nil {
+		idx.Close()
+		return false
+	}
+
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		target := r.URL.Path + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return true
+	}
+
+	entries, err := f.Readdir(-1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return true
+	}
+	entries = s.filterIgnoredEntries(entries)
+	sortBrowseEntries(entries, r.URL.Query().Get("sort"), r.URL.Query().Get("order"))
+
+	data := struct {
+		Path    string
+		Parent  string
+		Entries []browseEntry
+	}{
+		Path:   r.URL.Path,
+		Parent: parentPath(r.URL.Path),
+	}
+	for _, e := range entries {
+		data.Entries = append(data.Entries, browseEntry{
+			Name:    e.Name(),
+			IsDir:   e.IsDir(),
+			Size:    humanSize(e.Size()),
+			ModTime: e.ModTime(),
+		})
+	}
+
+	tmpl := s.BrowseTemplate
+	if tmpl == nil {
+		tmpl = defaultBrowseTemplate
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, data); err != nil {
+		log.Printf("render directory listing for %s: %s\n", r.URL.Path, err)
+	}
+	return true
+}
+
+// filterIgnoredEntries drops dotfiles and anything matching a pattern in
+// s.BrowseIgnore (as interpreted by path.Match).
+func (s *Site) filterIgnoredEntries(entries []fs.FileInfo) []fs.FileInfo {
+	kept := entries[:0]
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		ignored := false
+		for _, pattern := range s.BrowseIgnore {
+			if matched, _ := path.Match(pattern, e.Name()); matched {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+func sortBrowseEntries(entries []fs.FileInfo, sortBy, order string) {
+	less := func(i, j int) bool {
+		switch sortBy {
+		case "size":
+			return entries[i].Size() < entries[j].Size()
+		case "time":
+			return entries[i].ModTime().Before(entries[j].ModTime())
+		default:
+			return entries[i].Name() < entries[j].Name()
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if order == "desc" {
+			return less(j, i)
+		}
+		return less(i, j)
+	})
+}
+
+func parentPath(p string) string {
+	if p == "/" {
+		return ""
+	}
+	parent := path.Dir(strings.TrimSuffix(p, "/"))
+	if parent == "." {
+		parent = "/"
+	}
+	if !strings.HasSuffix(parent, "/") {
+		parent += "/"
+	}
+	return parent
+}
+
+func humanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
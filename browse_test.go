@@ -0,0 +1,86 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestServeBrowse(t *testing.T) {
+	back := tempDir(t)
+	defer back()
+
+	if err := os.MkdirAll("output/photos", 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"b.jpg", "a.jpg", ".hidden"} {
+		if err := os.WriteFile("output/photos/"+name, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	noop := ConfigureFunc(func(ctx context.Context, s *Site) error { return nil })
+	s, err := New(context.Background(), noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.BrowseDirs = true
+
+	t.Run("redirects without trailing slash", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/photos", nil)
+		rec := httptest.NewRecorder()
+		if !s.serveBrowse(rec, req, http.Dir("output")) {
+			t.Fatal("expected serveBrowse to handle the request")
+		}
+		if rec.Code != http.StatusMovedPermanently {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusMovedPermanently)
+		}
+	})
+
+	t.Run("lists entries, skipping dotfiles", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/photos/", nil)
+		rec := httptest.NewRecorder()
+		if !s.serveBrowse(rec, req, http.Dir("output")) {
+			t.Fatal("expected serveBrowse to handle the request")
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, "a.jpg") || !strings.Contains(body, "b.jpg") {
+			t.Errorf("expected listing to contain both files, got: %s", body)
+		}
+		if strings.Contains(body, ".hidden") {
+			t.Error("expected dotfile to be skipped")
+		}
+		if strings.Index(body, "a.jpg") > strings.Index(body, "b.jpg") {
+			t.Error("expected default name sort to list a.jpg before b.jpg")
+		}
+	})
+
+	t.Run("redirect without trailing slash preserves query", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/photos?sort=name&order=desc", nil)
+		rec := httptest.NewRecorder()
+		if !s.serveBrowse(rec, req, http.Dir("output")) {
+			t.Fatal("expected serveBrowse to handle the request")
+		}
+		if rec.Code != http.StatusMovedPermanently {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusMovedPermanently)
+		}
+		if got, want := rec.Header().Get("Location"), "/photos/?sort=name&order=desc"; got != want {
+			t.Errorf("Location = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("honors sort=name&order=desc", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/photos/?sort=name&order=desc", nil)
+		rec := httptest.NewRecorder()
+		if !s.serveBrowse(rec, req, http.Dir("output")) {
+			t.Fatal("expected serveBrowse to handle the request")
+		}
+		body := rec.Body.String()
+		if strings.Index(body, "b.jpg") > strings.Index(body, "a.jpg") {
+			t.Error("expected desc name sort to list b.jpg before a.jpg")
+		}
+	})
+}
@@ -19,3 +19,16 @@ func contextWithSite(ctx context.Context, site *Site) context.Context {
 func Asset(ctx context.Context, name string) string {
 	return SiteFromContext(ctx).Asset(name)
 }
+
+// AssetBlurHash returns a compact placeholder string for the named image
+// asset, suitable for inlining as a CSS background before the real image
+// loads.
+func AssetBlurHash(ctx context.Context, name string) string {
+	return SiteFromContext(ctx).BlurHash(name)
+}
+
+// AssetIntegrity returns the Subresource Integrity attribute value
+// (sha384-...) computed for the named asset.
+func AssetIntegrity(ctx context.Context, name string) string {
+	return SiteFromContext(ctx).Integrity(name)
+}
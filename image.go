@@ -0,0 +1,184 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// ImageOption customizes a variant produced by Site.ImageVariants.
+type ImageOption func(*imageOptions)
+
+type imageOptions struct {
+	width, height int
+	format        string
+	quality       int
+}
+
+// Resize sets the target dimensions of a variant. A zero width or height
+// preserves the original aspect ratio for the other dimension.
+func Resize(width, height int) ImageOption {
+	return func(o *imageOptions) {
+		o.width = width
+		o.height = height
+	}
+}
+
+// Format sets the output format of a variant: "jpeg", "png", "webp" or
+// "avif". webp and avif require building with the matching build tag.
+func Format(format string) ImageOption {
+	return func(o *imageOptions) {
+		o.format = format
+	}
+}
+
+// Quality sets the encoding quality (0-100) of a variant, where the
+// chosen format supports it.
+func Quality(quality int) ImageOption {
+	return func(o *imageOptions) {
+		o.quality = quality
+	}
+}
+
+// ImageVariants registers an additional variant to be generated for the
+// named image asset during Build, e.g.:
+//
+//	s.ImageVariants("photo.png", web.Resize(480, 0), web.Format("webp"))
+//
+// Call it once per desired variant. Variants are resolved via
+// Asset(ctx, "photo.png@w480.webp").
+func (s *Site) ImageVariants(name string, opts ...ImageOption) {
+	o := imageOptions{quality: 85}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s.imageVariants[name] = append(s.imageVariants[name], o)
+}
+
+// BlurHash returns the placeholder computed for the named asset by
+// processImage. It panics if name is not a processed image asset.
+func (s *Site) BlurHash(name string) string {
+	hash, ok := s.blurHashes[name]
+	if !ok {
+		panic(fmt.Sprintf("blurhash for asset %q not found", name))
+	}
+	return hash
+}
+
+// processImage computes a BlurHash placeholder and any registered
+// variants for the asset at name, whose fingerprinted copy already lives
+// in destDir. Non-image assets are ignored.
+func (s *Site) processImage(name, digest string, data []byte, destDir string) error {
+	if !strings.HasPrefix(http.DetectContentType(data), "image/") {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		// Sniffed as an image but not decodable by the registered
+		// codecs (GIF, WebP, BMP, ...): copy it through untouched
+		// rather than failing the whole build.
+		log.Printf("skip image processing for %s: decode: %s\n", name, err)
+		return nil
+	}
+
+	s.blurHashes[name] = encodeBlurHash(img)
+
+	for _, o := range s.imageVariants[name] {
+		variant := resizeImage(img, o.width, o.height)
+		encoded, ext, err := encodeImage(variant, o)
+		if err != nil {
+			return fmt.Errorf("encode variant: %w", err)
+		}
+		fileName := variantFileName(name, digest, o.width, o.height, ext)
+		if err := os.WriteFile(filepath.Join(destDir, fileName), encoded, 0644); err != nil {
+			return err
+		}
+		s.assets[variantKey(name, o.width, o.height, ext)] = filepath.Join(filepath.Dir(name), fileName)
+	}
+	return nil
+}
+
+func variantFileName(name, digest string, width, height int, ext string) string {
+	base := filepath.Base(name)
+	if i := strings.LastIndex(base, "."); i != -1 {
+		base = base[:i]
+	}
+	return fmt.Sprintf("%s.%s.%s.%s", base, digest, dimensionSuffix(width, height), ext)
+}
+
+func variantKey(name string, width, height int, ext string) string {
+	return fmt.Sprintf("%s@%s.%s", name, dimensionSuffix(width, height), ext)
+}
+
+func dimensionSuffix(width, height int) string {
+	switch {
+	case width > 0 && height > 0:
+		return fmt.Sprintf("w%dh%d", width, height)
+	case width > 0:
+		return fmt.Sprintf("w%d", width)
+	case height > 0:
+		return fmt.Sprintf("h%d", height)
+	default:
+		return "orig"
+	}
+}
+
+// resizeImage scales src to width x height, preserving the aspect ratio
+// when one dimension is zero. It returns src unchanged if both are zero.
+func resizeImage(src image.Image, width, height int) image.Image {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	switch {
+	case width > 0 && height == 0:
+		height = int(math.Round(float64(width) * float64(sh) / float64(sw)))
+	case height > 0 && width == 0:
+		width = int(math.Round(float64(height) * float64(sw) / float64(sh)))
+	case width == 0 && height == 0:
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, sb, draw.Over, nil)
+	return dst
+}
+
+func encodeImage(img image.Image, o imageOptions) (data []byte, ext string, err error) {
+	format := o.format
+	if format == "" {
+		format = "jpeg"
+	}
+	quality := o.quality
+	if quality <= 0 {
+		quality = 85
+	}
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg", "jpg":
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "jpeg", nil
+	case "png":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "png", nil
+	case "webp":
+		data, err := encodeWebP(img, quality)
+		return data, "webp", err
+	case "avif":
+		data, err := encodeAVIF(img, quality)
+		return data, "avif", err
+	default:
+		return nil, "", fmt.Errorf("unsupported image format %q", format)
+	}
+}
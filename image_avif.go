@@ -0,0 +1,14 @@
+//go:build !avif
+
+package web
+
+import (
+	"fmt"
+	"image"
+)
+
+// encodeAVIF is a stub used when the avif build tag is not set. Build
+// with -tags avif to link in a real avif encoder.
+func encodeAVIF(img image.Image, quality int) ([]byte, error) {
+	return nil, fmt.Errorf("avif encoding requires building with -tags avif")
+}
@@ -0,0 +1,18 @@
+//go:build avif
+
+package web
+
+import (
+	"bytes"
+	"image"
+
+	"github.com/Kagami/go-avif"
+)
+
+func encodeAVIF(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := avif.Encode(&buf, img, &avif.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,91 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"testing"
+)
+
+func TestImageVariantsAndBlurHash(t *testing.T) {
+	back := tempDir(t)
+	defer back()
+
+	ctx := context.Background()
+
+	initialConfigurer := ConfigureFunc(func(ctx context.Context, s *Site) error {
+		s.ImageVariants("photo.png", Resize(4, 0), Format("jpeg"))
+		s.ConfigureFunc(func(ctx context.Context, s *Site) error {
+			if err := os.Mkdir("assets", 0755); err != nil {
+				return err
+			}
+			img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+			for y := 0; y < 8; y++ {
+				for x := 0; x < 8; x++ {
+					img.Set(x, y, color.RGBA{R: uint8(x * 30), G: uint8(y * 30), B: 100, A: 255})
+				}
+			}
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, img); err != nil {
+				return err
+			}
+			return os.WriteFile("assets/photo.png", buf.Bytes(), 0644)
+		})
+		return nil
+	})
+
+	site, err := New(ctx, initialConfigurer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Build(contextWithSite(ctx, site)); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("variant written and resolvable", func(t *testing.T) {
+		asset := site.Asset("photo.png@w4.jpeg")
+		if _, err := os.Stat("output" + asset); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("blurhash computed", func(t *testing.T) {
+		hash := site.BlurHash("photo.png")
+		if len(hash) == 0 {
+			t.Error("expected non-empty blurhash")
+		}
+	})
+}
+
+func TestBuildCopiesThroughUndecodableImage(t *testing.T) {
+	back := tempDir(t)
+	defer back()
+
+	ctx := context.Background()
+
+	// Sniffed as image/gif by http.DetectContentType, but the GIF codec
+	// isn't blank-imported, so image.Decode can't handle it. It should
+	// still be copied through rather than failing the build.
+	configurer := ConfigureFunc(func(ctx context.Context, s *Site) error {
+		if err := os.Mkdir("assets", 0755); err != nil {
+			return err
+		}
+		return os.WriteFile("assets/pic.gif", []byte("GIF89a"), 0644)
+	})
+
+	site, err := New(ctx, configurer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Build(contextWithSite(ctx, site)); err != nil {
+		t.Fatal(err)
+	}
+
+	asset := site.Asset("pic.gif")
+	if _, err := os.Stat("output" + asset); err != nil {
+		t.Fatal(err)
+	}
+}
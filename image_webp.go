@@ -0,0 +1,14 @@
+//go:build !webp
+
+package web
+
+import (
+	"fmt"
+	"image"
+)
+
+// encodeWebP is a stub used when the webp build tag is not set. Build
+// with -tags webp to link in a real webp encoder.
+func encodeWebP(img image.Image, quality int) ([]byte, error) {
+	return nil, fmt.Errorf("webp encoding requires building with -tags webp")
+}
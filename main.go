@@ -3,13 +3,15 @@ package web
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 )
 
 func usage() {
-	fmt.Fprintf(os.Stderr, "usage: web build\n")
+	fmt.Fprintf(os.Stderr, "usage: web build [site.zip]\n")
 	fmt.Fprintf(os.Stderr, "       web serve\n")
+	fmt.Fprintf(os.Stderr, "       web dev\n")
 	os.Exit(2)
 }
 
@@ -31,6 +33,18 @@ func Main(configurer Configurer) {
 	switch os.Args[1] {
 	case "build":
 		log.Println("building")
+		if len(os.Args) >= 3 {
+			bundle, err := ZipSource(os.Args[2])
+			if err != nil {
+				log.Fatalf("build: %s\n", err)
+			}
+			if assetsFS, err := fs.Sub(bundle, site.AssetsDir); err == nil {
+				site.SetAssets(assetsFS)
+			}
+			if publicFS, err := fs.Sub(bundle, site.PublicDir); err == nil {
+				site.SetPublic(publicFS)
+			}
+		}
 		if err := site.Build(ctx); err != nil {
 			log.Fatalf("build: %s\n", err)
 		}
@@ -39,6 +53,14 @@ func Main(configurer Configurer) {
 		if err := site.Serve(":8080"); err != nil {
 			log.Fatalf("serve: %s\n", err)
 		}
+	case "dev":
+		if err := site.Build(ctx); err != nil {
+			log.Fatalf("build: %s\n", err)
+		}
+		log.Println("serving on http://localhost:8080 (live reload enabled)")
+		if err := site.ServeDev(ctx, ":8080"); err != nil {
+			log.Fatalf("serve: %s\n", err)
+		}
 	default:
 		usage()
 	}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// precompressibleExt lists the extensions copyAssets precompresses.
+// Already-compressed formats (images, fonts, ...) are skipped.
+var precompressibleExt = map[string]bool{
+	".css":  true,
+	".js":   true,
+	".svg":  true,
+	".html": true,
+	".json": true,
+	".xml":  true,
+	".wasm": true,
+}
+
+// precompressAsset writes gzip and brotli siblings of destPath (destPath
+// + ".gz" and destPath + ".br") when its extension is text-like, so
+// Serve can hand matching clients the compressed file directly.
+func precompressAsset(destPath string, data []byte) error {
+	if !precompressibleExt[strings.ToLower(filepath.Ext(destPath))] {
+		return nil
+	}
+
+	var gz bytes.Buffer
+	gzw, err := gzip.NewWriterLevel(&gz, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := gzw.Write(data); err != nil {
+		return err
+	}
+	if err := gzw.Close(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(destPath+".gz", gz.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	var br bytes.Buffer
+	brw := brotli.NewWriter(&br)
+	if _, err := brw.Write(data); err != nil {
+		return err
+	}
+	if err := brw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(destPath+".br", br.Bytes(), 0644)
+}
+
+// integrityDigest returns a Subresource Integrity attribute value
+// (sha384-...) for data.
+func integrityDigest(data []byte) string {
+	sum := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// servePrecompressed serves a precompressed sibling of the requested
+// file when one exists and the client's Accept-Encoding allows it. It
+// reports whether it handled the request.
+func servePrecompressed(w http.ResponseWriter, r *http.Request, dir http.Dir) bool {
+	if precompressibleExt[strings.ToLower(filepath.Ext(r.URL.Path))] {
+		// Set Vary unconditionally, even if we end up serving the plain
+		// file below, so a shared cache doesn't pin the first response
+		// (compressed or not) to every later Accept-Encoding.
+		w.Header().Set("Vary", "Accept-Encoding")
+	}
+
+	accept := r.Header.Get("Accept-Encoding")
+	for _, enc := range [...]struct{ suffix, name string }{
+		{".br", "br"},
+		{".gz", "gzip"},
+	} {
+		if !strings.Contains(accept, enc.name) {
+			continue
+		}
+		f, err := dir.Open(r.URL.Path + enc.suffix)
+		if err != nil {
+			continue
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			continue
+		}
+
+		w.Header().Set("Content-Encoding", enc.name)
+		if ctype := mime.TypeByExtension(filepath.Ext(r.URL.Path)); ctype != "" {
+			w.Header().Set("Content-Type", ctype)
+		}
+		http.ServeContent(w, r, r.URL.Path, info.ModTime(), f)
+		f.Close()
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPrecompressedAssetsAndIntegrity(t *testing.T) {
+	back := tempDir(t)
+	defer back()
+
+	ctx := context.Background()
+	content := strings.Repeat("body { color: red; }\n", 50)
+
+	configurer := ConfigureFunc(func(ctx context.Context, s *Site) error {
+		if err := os.Mkdir("assets", 0755); err != nil {
+			return err
+		}
+		return os.WriteFile("assets/style.css", []byte(content), 0644)
+	})
+
+	site, err := New(ctx, configurer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Build(contextWithSite(ctx, site)); err != nil {
+		t.Fatal(err)
+	}
+
+	assetPath := site.Asset("style.css")
+
+	t.Run("gzip sibling decodes to original content", func(t *testing.T) {
+		data, err := os.ReadFile("output" + assetPath + ".gz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := io.ReadAll(gr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(decoded) != content {
+			t.Error("decoded gzip content does not match original")
+		}
+	})
+
+	t.Run("brotli sibling exists", func(t *testing.T) {
+		if _, err := os.Stat("output" + assetPath + ".br"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("integrity digest is exposed", func(t *testing.T) {
+		digest := site.Integrity("style.css")
+		if !strings.HasPrefix(digest, "sha384-") {
+			t.Errorf("expected sha384- prefix, got %q", digest)
+		}
+	})
+
+	t.Run("Serve honors Accept-Encoding", func(t *testing.T) {
+		dir := "output"
+		req := httptest.NewRequest("GET", assetPath, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		if !servePrecompressed(rec, req, http.Dir(dir)) {
+			t.Fatal("expected servePrecompressed to handle the request")
+		}
+		if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Errorf("Content-Encoding = %q, want gzip", got)
+		}
+		if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("Vary = %q, want Accept-Encoding", got)
+		}
+	})
+
+	t.Run("sets Vary even without Accept-Encoding", func(t *testing.T) {
+		dir := "output"
+		req := httptest.NewRequest("GET", assetPath, nil)
+		rec := httptest.NewRecorder()
+		if servePrecompressed(rec, req, http.Dir(dir)) {
+			t.Fatal("expected servePrecompressed to leave the plain file to the caller")
+		}
+		if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("Vary = %q, want Accept-Encoding so caches don't pin the uncompressed response", got)
+		}
+	})
+}
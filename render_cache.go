@@ -0,0 +1,224 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// cacheDir holds the persistent incremental build cache between runs of
+// Build.
+const cacheDir = ".web-cache"
+
+type renderEntry struct {
+	renderer Renderer
+	deps     []string
+}
+
+// cacheManifest records, per rendered path, the dependency digest and
+// cache key used on the last build that produced it.
+type cacheManifest struct {
+	Entries map[string]cacheManifestEntry `json:"entries"`
+}
+
+type cacheManifestEntry struct {
+	DepsDigest string `json:"deps_digest"`
+	CacheKey   string `json:"cache_key"`
+}
+
+type renderStat struct {
+	Path     string
+	Cached   bool
+	Duration time.Duration
+}
+
+// renderAll renders every registered path using a worker pool sized to
+// GOMAXPROCS. Renderers declared with deps whose dependencies haven't
+// changed since the last build reuse the cached output instead of being
+// invoked again. Errors are logged per page, matching Build's previous
+// behavior of not aborting the whole build on one page's failure.
+func (s *Site) renderAll(ctx context.Context) {
+	manifest := s.loadCacheManifest()
+	var manifestMu sync.Mutex
+
+	type job struct {
+		path  string
+		entry renderEntry
+	}
+	jobs := make(chan job)
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg    sync.WaitGroup
+		stats = make([]renderStat, 0, len(s.renderers))
+		mu    sync.Mutex
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				stat := s.renderOne(ctx, j.path, j.entry, manifest, &manifestMu)
+				mu.Lock()
+				stats = append(stats, stat)
+				mu.Unlock()
+			}
+		}()
+	}
+	for path, entry := range s.renderers {
+		jobs <- job{path: path, entry: entry}
+	}
+	close(jobs)
+	wg.Wait()
+
+	s.saveCacheManifest(manifest)
+	logRenderStats(stats)
+}
+
+func (s *Site) renderOne(ctx context.Context, path string, entry renderEntry, manifest *cacheManifest, manifestMu *sync.Mutex) renderStat {
+	start := time.Now()
+	depsDigest := s.depsDigest(entry.deps)
+
+	if len(entry.deps) > 0 {
+		manifestMu.Lock()
+		cached, ok := manifest.Entries[path]
+		manifestMu.Unlock()
+		if ok && cached.DepsDigest == depsDigest {
+			if err := s.copyFromCache(cached.CacheKey, path); err == nil {
+				return renderStat{Path: path, Cached: true, Duration: time.Since(start)}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := entry.renderer.Render(ctx, &buf); err != nil {
+		log.Printf("error rendering %s: %s\n", path, err)
+		return renderStat{Path: path, Duration: time.Since(start)}
+	}
+	output := buf.Bytes()
+
+	out := filepath.Join(s.OutputDir, path)
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		log.Printf("error rendering %s: %s\n", path, err)
+		return renderStat{Path: path, Duration: time.Since(start)}
+	}
+	if err := os.WriteFile(out, output, 0644); err != nil {
+		log.Printf("error rendering %s: %s\n", path, err)
+		return renderStat{Path: path, Duration: time.Since(start)}
+	}
+
+	cacheKey := cacheKeyFor(output, depsDigest)
+	if err := writeCacheFile(cacheKey, output); err != nil {
+		log.Printf("write cache for %s: %s\n", path, err)
+	} else {
+		manifestMu.Lock()
+		manifest.Entries[path] = cacheManifestEntry{DepsDigest: depsDigest, CacheKey: cacheKey}
+		manifestMu.Unlock()
+	}
+
+	return renderStat{Path: path, Duration: time.Since(start)}
+}
+
+// depsDigest hashes the resolved, fingerprinted paths of deps. Since an
+// asset's fingerprint changes whenever its content does, an unchanged
+// digest means every dependency is byte-for-byte the same as last build.
+func (s *Site) depsDigest(deps []string) string {
+	if len(deps) == 0 {
+		return ""
+	}
+	sorted := append([]string(nil), deps...)
+	sort.Strings(sorted)
+	h := sha256.New()
+	for _, dep := range sorted {
+		io.WriteString(h, dep)
+		io.WriteString(h, "=")
+		io.WriteString(h, s.assets[dep])
+		io.WriteString(h, "\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func cacheKeyFor(output []byte, depsDigest string) string {
+	h := sha256.New()
+	h.Write(output)
+	io.WriteString(h, depsDigest)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeCacheFile(key string, data []byte) error {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cacheDir, key), data, 0644)
+}
+
+func (s *Site) copyFromCache(key, path string) error {
+	data, err := os.ReadFile(filepath.Join(cacheDir, key))
+	if err != nil {
+		return err
+	}
+	out := filepath.Join(s.OutputDir, path)
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(out, data, 0644)
+}
+
+func (s *Site) loadCacheManifest() *cacheManifest {
+	empty := &cacheManifest{Entries: map[string]cacheManifestEntry{}}
+	data, err := os.ReadFile(filepath.Join(cacheDir, "manifest.json"))
+	if err != nil {
+		return empty
+	}
+	var m cacheManifest
+	if err := json.Unmarshal(data, &m); err != nil || m.Entries == nil {
+		return empty
+	}
+	return &m
+}
+
+func (s *Site) saveCacheManifest(m *cacheManifest) {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		log.Printf("write cache manifest: %s\n", err)
+		return
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		log.Printf("write cache manifest: %s\n", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(cacheDir, "manifest.json"), data, 0644); err != nil {
+		log.Printf("write cache manifest: %s\n", err)
+	}
+}
+
+// logRenderStats prints a Hugo-style summary of which pages were cached
+// versus rebuilt.
+func logRenderStats(stats []renderStat) {
+	var cached, built int
+	for _, stat := range stats {
+		status := "rendered"
+		if stat.Cached {
+			status = "cached"
+			cached++
+		} else {
+			built++
+		}
+		log.Printf("%s %s in %s\n", status, stat.Path, stat.Duration)
+	}
+	log.Printf("rendering done: %d built, %d cached\n", built, cached)
+}
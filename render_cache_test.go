@@ -0,0 +1,63 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestBuildReusesCachedRenderWhenDepsUnchanged(t *testing.T) {
+	back := tempDir(t)
+	defer back()
+
+	ctx := context.Background()
+	var renders int
+
+	configurer := ConfigureFunc(func(ctx context.Context, s *Site) error {
+		if err := os.MkdirAll("assets", 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile("assets/image.png", []byte("image"), 0644); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	render := func(ctx context.Context, w io.Writer) error {
+		renders++
+		fmt.Fprintf(w, "<img src=\"%s\">\n", Asset(ctx, "image.png"))
+		return nil
+	}
+
+	site, err := New(ctx, configurer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	site.RenderWithDeps("index.html", []string{"image.png"}, RenderFunc(render))
+	if err := site.Build(contextWithSite(ctx, site)); err != nil {
+		t.Fatal(err)
+	}
+
+	site2, err := New(ctx, configurer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	site2.RenderWithDeps("index.html", []string{"image.png"}, RenderFunc(render))
+	if err := site2.Build(contextWithSite(ctx, site2)); err != nil {
+		t.Fatal(err)
+	}
+
+	if renders != 1 {
+		t.Errorf("expected renderer to run once, ran %d times", renders)
+	}
+
+	data, err := os.ReadFile("output/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "<img src=\"/assets/image.6105d6c.png\">\n" {
+		t.Errorf("unexpected cached output: %q", data)
+	}
+}
@@ -0,0 +1,227 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	livereloadPath   = "/_web/livereload"
+	livereloadScript = `<script>(function(){var es=new EventSource("` + livereloadPath + `");es.onmessage=function(){location.reload()};})();</script>`
+	rebuildDebounce  = 200 * time.Millisecond
+)
+
+// Watch registers additional paths (files or directories) to watch for
+// changes when the site is served with ServeDev. AssetsDir and PublicDir
+// are watched automatically.
+func (s *Site) Watch(paths ...string) {
+	s.watches = append(s.watches, paths...)
+}
+
+// ServeDev serves the site like Serve, but additionally watches AssetsDir,
+// PublicDir and any paths registered via Watch. On change it rebuilds the
+// site and tells connected browsers to reload by injecting a small script
+// into text/html responses that listens on an SSE endpoint.
+func (s *Site) ServeDev(ctx context.Context, addr string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	s.addWatches(watcher)
+
+	reloader := newReloadBroker()
+	go s.watchLoop(ctx, watcher, reloader)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(livereloadPath, reloader.serveHTTP)
+	mux.Handle("/", s.devHandler())
+
+	log.Printf("watching %s\n", strings.Join(watcher.WatchList(), ", "))
+	return http.ListenAndServe(addr, mux)
+}
+
+func (s *Site) addWatches(watcher *fsnotify.Watcher) {
+	roots := append([]string{s.AssetsDir, s.PublicDir}, s.watches...)
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		info, err := os.Stat(root)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			if err := watcher.Add(root); err != nil {
+				log.Printf("watch %s: %s\n", root, err)
+			}
+			continue
+		}
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if err := watcher.Add(path); err != nil {
+					log.Printf("watch %s: %s\n", path, err)
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func (s *Site) watchLoop(ctx context.Context, watcher *fsnotify.Watcher, reloader *reloadBroker) {
+	var timer *time.Timer
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(rebuildDebounce, func() {
+				log.Printf("rebuilding after change to %s\n", event.Name)
+				if err := s.Build(ctx); err != nil {
+					log.Printf("rebuild: %s\n", err)
+					return
+				}
+				reloader.broadcast()
+			})
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("watch error: %s\n", err)
+		}
+	}
+}
+
+// devHandler wraps Site.fileHandler, so ServeDev gets the same
+// ".html"-suffix fallback, directory browsing and precompression
+// negotiation as Serve, and additionally injects the livereload script
+// into text/html responses.
+func (s *Site) devHandler() http.Handler {
+	handler := s.fileHandler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &injectingWriter{ResponseWriter: w}
+		handler.ServeHTTP(rec, r)
+		rec.flush()
+	})
+}
+
+// injectingWriter buffers a response body so the livereload script can be
+// inserted before the Content-Length header is written.
+type injectingWriter struct {
+	http.ResponseWriter
+	buf    bytes.Buffer
+	status int
+}
+
+func (w *injectingWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *injectingWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *injectingWriter) flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	body := w.buf.Bytes()
+	if w.Header().Get("Content-Encoding") == "" && strings.Contains(w.Header().Get("Content-Type"), "text/html") {
+		body = injectLivereloadScript(body)
+		w.Header().Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
+
+func injectLivereloadScript(body []byte) []byte {
+	const marker = "</body>"
+	i := bytes.LastIndex(body, []byte(marker))
+	if i == -1 {
+		return append(body, []byte(livereloadScript)...)
+	}
+	out := make([]byte, 0, len(body)+len(livereloadScript))
+	out = append(out, body[:i]...)
+	out = append(out, []byte(livereloadScript)...)
+	out = append(out, body[i:]...)
+	return out
+}
+
+// reloadBroker coalesces rapid filesystem changes into reload
+// notifications pushed to connected browsers over SSE.
+type reloadBroker struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+func newReloadBroker() *reloadBroker {
+	return &reloadBroker{clients: map[chan struct{}]struct{}{}}
+}
+
+func (b *reloadBroker) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	b.clients[ch] = struct{}{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, ch)
+		b.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+			fmt.Fprintf(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func (b *reloadBroker) broadcast() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestInjectLivereloadScript(t *testing.T) {
+	t.Run("inserts before closing body tag", func(t *testing.T) {
+		body := injectLivereloadScript([]byte("<html><body>hi</body></html>"))
+		if got := string(body); strings.Index(got, livereloadScript) == -1 ||
+			strings.Index(got, livereloadScript) > strings.Index(got, "</body>") {
+			t.Errorf("expected script before </body>, got %q", got)
+		}
+	})
+
+	t.Run("appends when no closing body tag is found", func(t *testing.T) {
+		body := injectLivereloadScript([]byte("<html>no body tag"))
+		if !strings.HasSuffix(string(body), livereloadScript) {
+			t.Errorf("expected script appended, got %q", body)
+		}
+	})
+}
+
+func TestDevHandlerInjectsScriptForHTMLOnly(t *testing.T) {
+	back := tempDir(t)
+	defer back()
+
+	ctx := context.Background()
+	configurer := ConfigureFunc(func(ctx context.Context, s *Site) error {
+		s.RenderFunc("index.html", func(ctx context.Context, w io.Writer) error {
+			fmt.Fprint(w, "<html><body>hi</body></html>")
+			return nil
+		})
+		s.ConfigureFunc(func(ctx context.Context, s *Site) error {
+			if err := os.Mkdir("public", 0755); err != nil {
+				return err
+			}
+			return os.WriteFile("public/style.css", []byte("body{color:red}"), 0644)
+		})
+		return nil
+	})
+
+	site, err := New(ctx, configurer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Build(contextWithSite(ctx, site)); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := site.devHandler()
+
+	t.Run("injects script into HTML response", func(t *testing.T) {
+		// http.FileServer redirects a literal request for "/index.html"
+		// to "/" with an empty body, so request "/" to actually exercise
+		// the injection.
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if !strings.Contains(rec.Body.String(), livereloadScript) {
+			t.Errorf("expected livereload script in response, got %q", rec.Body.String())
+		}
+	})
+
+	t.Run("leaves non-HTML response untouched", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/style.css", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if strings.Contains(rec.Body.String(), livereloadScript) {
+			t.Errorf("did not expect livereload script in CSS response, got %q", rec.Body.String())
+		}
+	})
+}
+
+func TestWatchLoopDebouncesRapidChanges(t *testing.T) {
+	back := tempDir(t)
+	defer back()
+
+	var builds int32
+	configurer := ConfigureFunc(func(ctx context.Context, s *Site) error {
+		s.RenderFunc("index.html", func(ctx context.Context, w io.Writer) error {
+			atomic.AddInt32(&builds, 1)
+			fmt.Fprint(w, "ok")
+			return nil
+		})
+		return nil
+	})
+
+	ctx := context.Background()
+	site, err := New(ctx, configurer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+
+	reloader := newReloadBroker()
+	go site.watchLoop(contextWithSite(ctx, site), watcher, reloader)
+
+	for i := 0; i < 5; i++ {
+		watcher.Events <- fsnotify.Event{Name: "assets/style.css", Op: fsnotify.Write}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	time.Sleep(rebuildDebounce + 150*time.Millisecond)
+
+	if got := atomic.LoadInt32(&builds); got != 1 {
+		t.Errorf("builds = %d, want 1 after coalescing rapid events", got)
+	}
+}
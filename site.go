@@ -4,7 +4,9 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"html/template"
 	"io"
 	"io/fs"
 	"log"
@@ -40,10 +42,26 @@ type Site struct {
 	PublicDir string
 	AssetsDir string
 
-	configurers []Configurer
-	renderers   map[string]Renderer
-	assets      map[string]string
-	commands    []string
+	// BrowseDirs enables a directory listing for Serve when a request
+	// maps to a directory with no index.html. See also WithBrowse.
+	BrowseDirs bool
+	// BrowseTemplate renders the directory listing when BrowseDirs is
+	// set. It defaults to a minimal built-in template.
+	BrowseTemplate *template.Template
+	// BrowseIgnore lists additional path.Match patterns to exclude from
+	// directory listings. Dotfiles are always excluded.
+	BrowseIgnore []string
+
+	configurers   []Configurer
+	renderers     map[string]renderEntry
+	assets        map[string]string
+	commands      []string
+	assetsSource  fs.FS
+	publicSource  fs.FS
+	watches       []string
+	imageVariants map[string][]imageOptions
+	blurHashes    map[string]string
+	integrity     map[string]string
 }
 
 func New(ctx context.Context, initialConfigurer Configurer) (*Site, error) {
@@ -52,9 +70,12 @@ func New(ctx context.Context, initialConfigurer Configurer) (*Site, error) {
 		PublicDir: "public",
 		AssetsDir: "assets",
 
-		configurers: []Configurer{initialConfigurer},
-		renderers:   map[string]Renderer{},
-		assets:      map[string]string{},
+		configurers:   []Configurer{initialConfigurer},
+		renderers:     map[string]renderEntry{},
+		assets:        map[string]string{},
+		imageVariants: map[string][]imageOptions{},
+		blurHashes:    map[string]string{},
+		integrity:     map[string]string{},
 	}
 	for len(s.configurers) > 0 {
 		configurer := s.configurers[0]
@@ -75,17 +96,39 @@ func (s *Site) ConfigureFunc(configurer func(ctx context.Context, s *Site) error
 }
 
 func (s *Site) Render(path string, renderer Renderer) {
-	s.renderers[path] = renderer
+	s.RenderWithDeps(path, nil, renderer)
 }
 
 func (s *Site) RenderFunc(path string, renderer func(ctx context.Context, w io.Writer) error) {
 	s.Render(path, RenderFunc(renderer))
 }
 
+// RenderWithDeps registers renderer for path like Render, additionally
+// declaring the names of assets it reads while rendering. When none of
+// those assets' content has changed since the last build, Build reuses
+// the previously written output instead of invoking renderer again.
+func (s *Site) RenderWithDeps(path string, deps []string, renderer Renderer) {
+	s.renderers[path] = renderEntry{renderer: renderer, deps: deps}
+}
+
 func (s *Site) Run(command string) {
 	s.commands = append(s.commands, command)
 }
 
+// SetAssets makes the site read its assets from fsys instead of AssetsDir
+// on disk. This allows a site to be built from, for example, an embed.FS
+// or a filesystem returned by ZipSource.
+func (s *Site) SetAssets(fsys fs.FS) {
+	s.assetsSource = fsys
+}
+
+// SetPublic makes the site read its public files from fsys instead of
+// PublicDir on disk. This allows a site to be built from, for example, an
+// embed.FS or a filesystem returned by ZipSource.
+func (s *Site) SetPublic(fsys fs.FS) {
+	s.publicSource = fsys
+}
+
 func (s *Site) Build(ctx context.Context) error {
 	if err := os.RemoveAll(s.OutputDir); err != nil {
 		return fmt.Errorf("remove output dir: %w", err)
@@ -102,11 +145,7 @@ func (s *Site) Build(ctx context.Context) error {
 	if err := s.copyPublicFiles(); err != nil {
 		return fmt.Errorf("copy public files: %w", err)
 	}
-	for file, renderer := range s.renderers {
-		if err := s.render(ctx, file, renderer); err != nil {
-			log.Printf("error rendering %s: %s\n", file, err)
-		}
-	}
+	s.renderAll(ctx)
 	return nil
 }
 
@@ -118,12 +157,31 @@ func (s *Site) Asset(name string) string {
 	return "/" + s.AssetsDir + "/" + name
 }
 
+// Integrity returns the Subresource Integrity attribute value
+// (sha384-...) computed for the named asset, for use in e.g.
+// <script src="..." integrity="..." crossorigin="anonymous">.
+func (s *Site) Integrity(name string) string {
+	digest, ok := s.integrity[name]
+	if !ok {
+		panic(fmt.Sprintf("integrity digest for asset %q not found", name))
+	}
+	return digest
+}
+
 func (s *Site) Serve(addr string) error {
+	return http.ListenAndServe(addr, s.fileHandler())
+}
+
+// fileHandler serves s.OutputDir: it rewrites extension-less paths to
+// their ".html" sibling, renders a directory listing when BrowseDirs is
+// set, and negotiates precompressed asset variants. It backs both Serve
+// and ServeDev so the two entry points can't drift apart.
+func (s *Site) fileHandler() http.Handler {
 	var (
-		dir = http.Dir(s.OutputDir)
-		fs  = http.FileServer(dir)
+		dir  = http.Dir(s.OutputDir)
+		fsrv = http.FileServer(dir)
 	)
-	return http.ListenAndServe(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !strings.HasSuffix(r.URL.Path, ".html") && !strings.HasSuffix(r.URL.Path, "/") {
 			p := r.URL.Path[1:] + ".html"
 			file, err := dir.Open(p)
@@ -132,28 +190,14 @@ func (s *Site) Serve(addr string) error {
 				r.URL.Path += ".html"
 			}
 		}
-		fs.ServeHTTP(w, r)
-	}))
-}
-
-func (s *Site) render(ctx context.Context, file string, renderer Renderer) error {
-	log.Printf("rendering %s\n", file)
-	out := s.OutputDir + "/" + file
-	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
-		return err
-	}
-	f, err := os.Create(out)
-	if err != nil {
-		return err
-	}
-	if err := renderer.Render(ctx, f); err != nil {
-		f.Close()
-		return err
-	}
-	if err := f.Close(); err != nil {
-		return err
-	}
-	return nil
+		if s.BrowseDirs && s.serveBrowse(w, r, dir) {
+			return
+		}
+		if servePrecompressed(w, r, dir) {
+			return
+		}
+		fsrv.ServeHTTP(w, r)
+	})
 }
 
 func (s *Site) runCommands(ctx context.Context) error {
@@ -174,19 +218,27 @@ func (s *Site) runCommands(ctx context.Context) error {
 }
 
 func (s *Site) copyAssets() error {
-	if _, err := os.Stat(s.AssetsDir); os.IsNotExist(err) {
+	fsys := s.assetsSource
+	if fsys == nil {
+		fsys = os.DirFS(s.AssetsDir)
+	}
+	if _, err := fs.Stat(fsys, "."); errors.Is(err, fs.ErrNotExist) {
 		return nil
+	} else if err != nil {
+		return err
 	}
-	return filepath.Walk(s.AssetsDir, func(path string, info fs.FileInfo, err error) error {
+	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() {
-			return os.MkdirAll(filepath.Join(s.OutputDir, path), 0755)
+		if path == "." {
+			return nil
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(s.OutputDir, s.AssetsDir, path), 0755)
 		}
-		name := strings.TrimPrefix(path, s.AssetsDir+"/")
-		log.Printf("copying asset %s\n", name)
-		data, err := os.ReadFile(path)
+		log.Printf("copying asset %s\n", path)
+		data, err := fs.ReadFile(fsys, path)
 		if err != nil {
 			return err
 		}
@@ -200,37 +252,57 @@ func (s *Site) copyAssets() error {
 		} else {
 			fileName += "." + digest
 		}
-		destPath := filepath.Join(s.OutputDir, filepath.Dir(path), fileName)
+		destPath := filepath.Join(s.OutputDir, s.AssetsDir, filepath.Dir(path), fileName)
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return err
+		}
 		if err := os.WriteFile(destPath, data, 0644); err != nil {
 			return err
 		}
-		s.assets[name] = filepath.Join(filepath.Dir(name), fileName)
+		s.assets[path] = filepath.Join(filepath.Dir(path), fileName)
+		s.integrity[path] = integrityDigest(data)
+		if err := precompressAsset(destPath, data); err != nil {
+			return fmt.Errorf("precompress asset %s: %w", path, err)
+		}
+		if err := s.processImage(path, digest, data, filepath.Dir(destPath)); err != nil {
+			return fmt.Errorf("process image %s: %w", path, err)
+		}
 		return nil
 	})
 }
 
 func (s *Site) copyPublicFiles() error {
-	if _, err := os.Stat(s.PublicDir); os.IsNotExist(err) {
+	fsys := s.publicSource
+	if fsys == nil {
+		fsys = os.DirFS(s.PublicDir)
+	}
+	if _, err := fs.Stat(fsys, "."); errors.Is(err, fs.ErrNotExist) {
 		return nil
+	} else if err != nil {
+		return err
 	}
-	return filepath.Walk(s.PublicDir, func(path string, info fs.FileInfo, err error) error {
+	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if path == s.PublicDir {
+		if path == "." {
 			return nil
 		}
-		destPath := s.OutputDir + "/" + strings.TrimPrefix(path, s.PublicDir+"/")
-		if info.IsDir() {
+		destPath := filepath.Join(s.OutputDir, path)
+		if d.IsDir() {
 			return os.MkdirAll(destPath, 0755)
 		}
-		dest, err := os.Create(destPath)
+		src, err := fsys.Open(path)
 		if err != nil {
 			return err
 		}
-		src, err := os.Open(path)
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			src.Close()
+			return err
+		}
+		dest, err := os.Create(destPath)
 		if err != nil {
-			dest.Close()
+			src.Close()
 			return err
 		}
 		if _, err := io.Copy(dest, src); err != nil {
@@ -242,9 +314,6 @@ func (s *Site) copyPublicFiles() error {
 			src.Close()
 			return err
 		}
-		if err := src.Close(); err != nil {
-			return err
-		}
-		return nil
+		return src.Close()
 	})
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestBuild(t *testing.T) {
@@ -92,6 +94,87 @@ func TestBuild(t *testing.T) {
 	})
 }
 
+func TestBuildWithFSSource(t *testing.T) {
+	back := tempDir(t)
+	defer back()
+
+	ctx := context.Background()
+
+	mapFS := fstest.MapFS{
+		"image.png": &fstest.MapFile{Data: []byte("image")},
+	}
+
+	initialConfigurer := ConfigureFunc(func(ctx context.Context, s *Site) error {
+		s.SetAssets(mapFS)
+		s.RenderFunc("index.html", func(ctx context.Context, w io.Writer) error {
+			fmt.Fprintf(w, "<img src=\"%s\">\n", Asset(ctx, "image.png"))
+			return nil
+		})
+		return nil
+	})
+
+	site, err := New(ctx, initialConfigurer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Build(contextWithSite(ctx, site)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile("output/assets/image.6105d6c.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "image" {
+		t.Error("unexpected file content")
+	}
+}
+
+func TestBuildWithMissingFSSourceRoot(t *testing.T) {
+	back := tempDir(t)
+	defer back()
+
+	ctx := context.Background()
+
+	// A bundle that only has a "public/" directory, like a zip built
+	// without an assets/ entry. fs.Sub happily returns an fs.FS for the
+	// missing "assets" subtree; Build must tolerate that the same way it
+	// tolerates a missing AssetsDir on disk.
+	bundle := fstest.MapFS{
+		"public/robots.txt": &fstest.MapFile{Data: []byte("robot")},
+	}
+	assetsFS, err := fs.Sub(bundle, "assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicFS, err := fs.Sub(bundle, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	initialConfigurer := ConfigureFunc(func(ctx context.Context, s *Site) error {
+		s.SetAssets(assetsFS)
+		s.SetPublic(publicFS)
+		return nil
+	})
+
+	site, err := New(ctx, initialConfigurer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Build(contextWithSite(ctx, site)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile("output/robots.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "robot" {
+		t.Error("unexpected file content")
+	}
+}
+
 func tempDir(t *testing.T) func() {
 	wd, err := os.Getwd()
 	if err != nil {
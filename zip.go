@@ -0,0 +1,21 @@
+package web
+
+import (
+	"archive/zip"
+	"io/fs"
+)
+
+// ZipSource opens the zip archive at path and returns an fs.FS backed by
+// its contents. The result can be passed to Site.SetAssets or
+// Site.SetPublic to build a site from a .zip bundle instead of a working
+// directory. The returned fs.FS also implements io.Closer
+// (*zip.ReadCloser); callers that outlive a single Build, such as a
+// future long-running dev server, should close it once done to release
+// the underlying file handle.
+func ZipSource(path string) (fs.FS, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}